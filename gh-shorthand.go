@@ -4,21 +4,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/mitchellh/go-homedir"
-	"github.com/zerowidth/gh-shorthand/alfred"
-	"github.com/zerowidth/gh-shorthand/config"
-	"github.com/zerowidth/gh-shorthand/parser"
+	"github.com/zerowidth/gh-shorthand/pkg/alfred"
+	"github.com/zerowidth/gh-shorthand/pkg/config"
+	"github.com/zerowidth/gh-shorthand/pkg/enrich"
+	"github.com/zerowidth/gh-shorthand/pkg/parser"
+	"github.com/zerowidth/gh-shorthand/pkg/search"
+	"net/url"
 	"os"
 	"strings"
 )
 
 func main() {
-	var input string
-	if len(os.Args) < 2 {
-		input = ""
-	} else {
-		input = strings.Join(os.Args[1:], " ")
+	var args []string
+	if len(os.Args) > 1 {
+		args = os.Args[1:]
+	}
+
+	// `preview <input>` enriches a shorthand result with data from the
+	// GitHub API instead of just linking to it, and `search <input>` greps a
+	// local clone; everything else is treated as shorthand input to the
+	// default script filter.
+	subcommand := ""
+	if len(args) > 0 && (args[0] == "preview" || args[0] == "search") {
+		subcommand = args[0]
+		args = args[1:]
 	}
 
+	input := strings.Join(args, " ")
 	fmt.Fprintf(os.Stderr, "input: %#v\n", input)
 
 	path, _ := homedir.Expand("~/.gh-shorthand.yml")
@@ -27,37 +39,194 @@ func main() {
 		panic(err.Error())
 	}
 
-	items := generateItems(cfg, input)
+	// the default repo to fall back to is resolved here, by the
+	// subcommand/keyword that invoked the binary, not guessed later from
+	// whatever shorthand grammar happens to match the input.
+	defaultRepo := cfg.Defaults[subcommand]
+
+	var items []alfred.Item
+	switch subcommand {
+	case "preview":
+		items = generatePreviewItems(cfg, defaultRepo, input)
+	case "search":
+		items = generateSearchItems(cfg, defaultRepo, input)
+	default:
+		items = generateItems(cfg, defaultRepo, input)
+	}
+
 	doc := alfred.Items{Items: items}
 	if err := json.NewEncoder(os.Stdout).Encode(doc); err != nil {
 		panic(err.Error())
 	}
 }
 
-func generateItems(cfg *config.Config, input string) []alfred.Item {
+func generateItems(cfg *config.Config, defaultRepo, input string) []alfred.Item {
 	items := []alfred.Item{}
-	result := parser.Parse(cfg.RepoMap, input)
-	if result.Repo != "" {
-		uid := "gh:" + result.Repo
+	p := parser.NewParser(cfg.RepoMap, cfg.UserMap, defaultRepo,
+		parser.RequireRepo, parser.WithIssue, parser.WithPR, parser.WithAction, parser.WithQuery,
+		parser.WithHost(hostMap(cfg)))
+	result := p.Parse(input)
+	if result.HasRepo() {
+		uid := "gh:"
+		if result.Host != "" {
+			uid += result.Host + ":"
+		}
+		uid += result.Repo
 		title := "Open " + result.Repo
-		arg := "open https://github.com/" + result.Repo
 
 		if result.Issue != "" {
 			uid += "#" + result.Issue
 			title += "#" + result.Issue
-			arg += "/issues/" + result.Issue
 		}
-
+		if result.PR != "" {
+			uid += "!" + result.PR
+			title += "!" + result.PR
+		}
 		if result.Match != "" {
 			title += " (" + result.Match + ")"
 		}
 
-		items = append(items, alfred.Item{
+		item := alfred.Item{
 			UID:   uid,
 			Title: title + " on GitHub",
-			Arg:   arg,
+			Arg:   "open " + actionURL(result),
 			Valid: true,
-		})
+		}
+		if result.UsedDefaultRepo {
+			item.Subtitle = "using default repo " + result.Repo
+		}
+
+		// opt-in fan-out: when enrichment is configured and the result is a
+		// single issue, swap the plain link item for an enriched one.
+		if cfg.Preview && result.Action == "" && result.PR == "" && result.Issue != "" {
+			if enriched, err := newPreviewClient(cfg).Item(result.Repo, result.Issue); err == nil {
+				item = enriched
+			} else {
+				fmt.Fprintf(os.Stderr, "enrich: %s\n", err)
+			}
+		}
+
+		items = append(items, item)
+	} else {
+		for _, s := range result.Suggestions {
+			items = append(items, alfred.Item{
+				UID:          "gh-suggest:" + s.Key,
+				Title:        s.Key,
+				Subtitle:     "expands to " + s.Value,
+				Autocomplete: s.Value,
+				Valid:        false,
+			})
+		}
+	}
+	return items
+}
+
+// actionURL routes a parsed result to its URL on the matched host (or
+// github.com by default). An action verb (i, p, c, r, w, n) takes
+// precedence over a plain issue/PR number.
+func actionURL(result *parser.NewResult) string {
+	host := "https://github.com"
+	if result.HostBaseURL != "" {
+		host = result.HostBaseURL
+	}
+	base := host + "/" + result.Repo
+
+	switch result.Action {
+	case "issues":
+		// parseIssue runs regardless of Action, so a bare number after the
+		// action verb (e.g. "i zw 42") lands in Issue, not Query.
+		if result.Query == "" && result.Issue != "" {
+			return base + "/issues/" + result.Issue
+		}
+		return queryURL(base+"/issues", result.Query)
+	case "pulls":
+		if result.Query == "" && result.Issue != "" {
+			return base + "/pull/" + result.Issue
+		}
+		return queryURL(base+"/pulls", result.Query)
+	case "commits":
+		return base + "/commits"
+	case "releases":
+		return base + "/releases"
+	case "wiki":
+		return base + "/wiki"
+	case "new-issue":
+		u := base + "/issues/new"
+		if result.Query != "" {
+			u += "?title=" + url.QueryEscape(result.Query)
+		}
+		return u
+	}
+
+	if result.PR != "" {
+		return base + "/pull/" + result.PR
+	}
+	if result.Issue != "" {
+		return base + "/issues/" + result.Issue
+	}
+	return base
+}
+
+func queryURL(base, query string) string {
+	if query == "" {
+		return base
+	}
+	return base + "?q=" + url.QueryEscape(query)
+}
+
+// generatePreviewItems always enriches the parsed shorthand via the GitHub
+// API, for use by the `preview` subcommand.
+func generatePreviewItems(cfg *config.Config, defaultRepo, input string) []alfred.Item {
+	items := []alfred.Item{}
+	p := parser.NewParser(cfg.RepoMap, cfg.UserMap, defaultRepo, parser.RequireRepo, parser.WithIssue)
+	result := p.Parse(input)
+	if !result.HasRepo() || result.Issue == "" {
+		return items
+	}
+
+	item, err := newPreviewClient(cfg).Item(result.Repo, result.Issue)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "enrich: %s\n", err)
+		return items
+	}
+
+	return append(items, item)
+}
+
+// hostMap converts the hosts configured in ~/.gh-shorthand.yml into the
+// map[string]parser.Host the parser's WithHost option expects.
+func hostMap(cfg *config.Config) map[string]parser.Host {
+	hosts := make(map[string]parser.Host, len(cfg.Hosts))
+	for shorthand, host := range cfg.Hosts {
+		hosts[shorthand] = parser.Host{
+			BaseURL: host.BaseURL,
+			RepoMap: host.RepoMap,
+			UserMap: host.UserMap,
+		}
+	}
+	return hosts
+}
+
+func newPreviewClient(cfg *config.Config) *enrich.Client {
+	cacheDir, _ := homedir.Expand("~/.cache/gh-shorthand")
+	return enrich.NewClient(cfg.Token, cacheDir)
+}
+
+// generateSearchItems implements the `search` subcommand: `repo-shorthand
+// query text` greps the local clone of repo-shorthand for the query.
+func generateSearchItems(cfg *config.Config, defaultRepo, input string) []alfred.Item {
+	p := parser.NewParser(cfg.RepoMap, cfg.UserMap, defaultRepo, parser.WithCodeQuery)
+	result := p.Parse(input)
+	if !result.HasRepo() || result.Query == "" {
+		return []alfred.Item{}
+	}
+
+	cloneRoot, _ := homedir.Expand(cfg.CloneRoot)
+	searcher := search.NewSearcher(cloneRoot, cfg.Editor)
+	items, err := searcher.Search(result.Repo, result.Query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "search: %s\n", err)
+		return []alfred.Item{}
 	}
 	return items
 }
@@ -0,0 +1,36 @@
+// Package alfred implements the JSON document format for Alfred script
+// filter output.
+package alfred
+
+// Items is the top-level Alfred script filter document.
+type Items struct {
+	Items []Item `json:"items"`
+}
+
+// Item is a single Alfred script filter result.
+type Item struct {
+	UID          string         `json:"uid,omitempty"`
+	Title        string         `json:"title"`
+	Subtitle     string         `json:"subtitle,omitempty"`
+	Autocomplete string         `json:"autocomplete,omitempty"`
+	Arg          string         `json:"arg,omitempty"`
+	Icon         *Icon          `json:"icon,omitempty"`
+	Valid        bool           `json:"valid"`
+	Mods         map[string]Mod `json:"mods,omitempty"`
+}
+
+// Icon points Alfred at an image file to render next to an Item. Path is
+// relative to the workflow's bundle directory unless Type is "fileicon" or
+// "filetype", in which case it's a path (or UTI) to borrow the icon from.
+type Icon struct {
+	Path string `json:"path"`
+	Type string `json:"type,omitempty"`
+}
+
+// Mod describes how an Item's subtitle/arg change when a modifier key
+// (cmd, alt, ctrl, shift) is held.
+type Mod struct {
+	Subtitle string `json:"subtitle,omitempty"`
+	Arg      string `json:"arg,omitempty"`
+	Valid    bool   `json:"valid"`
+}
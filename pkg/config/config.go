@@ -0,0 +1,52 @@
+// Package config loads gh-shorthand's configuration file,
+// ~/.gh-shorthand.yml.
+package config
+
+import (
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config is the parsed contents of ~/.gh-shorthand.yml.
+type Config struct {
+	RepoMap map[string]string `yaml:"repos"`
+	UserMap map[string]string `yaml:"users"`
+
+	Token   string `yaml:"token"`   // GitHub API token, for the preview subcommand
+	Preview bool   `yaml:"preview"` // opt in to enriching default results via the GitHub API
+
+	CloneRoot string `yaml:"clone_root"` // local clone root for the search subcommand, e.g. ~/src/github.com
+	Editor    string `yaml:"editor"`     // command used to open a file found by search, e.g. "code -g"
+
+	// Hosts maps a host shorthand prefix (e.g. "work" in "work:team/api#3")
+	// to that host's base URL and its own repo/user shorthand maps.
+	Hosts map[string]HostConfig `yaml:"hosts"`
+
+	// Defaults maps the Alfred subcommand/keyword that invoked the binary
+	// (e.g. "search", "preview", or "" for the default keyword) to a
+	// default repo to use for that context when no repo is matched.
+	Defaults map[string]string `yaml:"defaults"`
+}
+
+// HostConfig describes a single GitHub-like host under the hosts: section.
+type HostConfig struct {
+	BaseURL string            `yaml:"base_url"`
+	RepoMap map[string]string `yaml:"repos"`
+	UserMap map[string]string `yaml:"users"`
+}
+
+// LoadFromFile reads and parses the config file at path.
+func LoadFromFile(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,223 @@
+// Package enrich fetches issue and pull request summaries from the GitHub
+// API and renders them as Alfred items. Results are cached on disk so that
+// Alfred's script-filter rerun cycle (every keystroke) doesn't hammer the
+// API or stall waiting on a round trip.
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zerowidth/gh-shorthand/pkg/alfred"
+)
+
+// DefaultTTL is how long a cached issue/PR summary is considered fresh
+// before it's re-fetched from the API.
+const DefaultTTL = 5 * time.Minute
+
+// Client fetches and caches issue/PR details from the GitHub API.
+type Client struct {
+	Token    string        // GitHub API token, from config
+	CacheDir string        // directory for on-disk response caching
+	TTL      time.Duration // cache freshness window, defaults to DefaultTTL
+
+	httpClient *http.Client
+}
+
+// NewClient returns a Client configured with the given API token and
+// on-disk cache directory.
+func NewClient(token, cacheDir string) *Client {
+	return &Client{
+		Token:      token,
+		CacheDir:   cacheDir,
+		TTL:        DefaultTTL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// cached is the on-disk cache envelope for a single repo#issue summary.
+type cached struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Summary   summary   `json:"summary"`
+}
+
+// summary is the subset of the GitHub issue/PR API response needed to
+// render an Alfred item. This is fetched from the issues endpoint (which
+// covers both issues and PRs), so merged state has to be derived from
+// PullRequest.MergedAt rather than read off a top-level "merged" field,
+// which that endpoint never returns.
+type summary struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	State string `json:"state"`
+	User  struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"labels"`
+	PullRequest *struct {
+		MergedAt *string `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+// merged reports whether the summary is a pull request that's been merged.
+func (s *summary) merged() bool {
+	return s.PullRequest != nil && s.PullRequest.MergedAt != nil
+}
+
+// RateLimitError is returned when the GitHub API reports that the request's
+// rate limit has been exhausted.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited until %s", e.Reset.Format(time.Kitchen))
+}
+
+// Item fetches (or loads from cache) the issue or PR summary for
+// repo#issue and renders it as an Alfred item.
+func (c *Client) Item(repo, issue string) (alfred.Item, error) {
+	key := repo + "#" + issue
+
+	s, err := c.cachedSummary(key)
+	if err != nil {
+		return alfred.Item{}, err
+	}
+	if s == nil {
+		s, err = c.fetch(repo, issue)
+		if err != nil {
+			return alfred.Item{}, err
+		}
+		c.writeCache(key, s)
+	}
+
+	return toItem(repo, issue, s), nil
+}
+
+func (c *Client) cachedSummary(key string) (*summary, error) {
+	if c.CacheDir == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(c.cachePath(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entry cached
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil // treat a corrupt cache entry as a miss
+	}
+
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if time.Since(entry.FetchedAt) > ttl {
+		return nil, nil
+	}
+
+	return &entry.Summary, nil
+}
+
+func (c *Client) writeCache(key string, s *summary) {
+	if c.CacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(cached{FetchedAt: time.Now(), Summary: *s})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.CacheDir, 0755); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(c.cachePath(key), data, 0644)
+}
+
+func (c *Client) cachePath(key string) string {
+	return filepath.Join(c.CacheDir, strings.NewReplacer("/", "-", "#", "-").Replace(key)+".json")
+}
+
+func (c *Client) fetch(repo, issue string) (*summary, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/issues/%s", repo, issue)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return nil, &RateLimitError{Reset: rateLimitReset(resp.Header.Get("X-RateLimit-Reset"))}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("enrich: %s returned %s", url, resp.Status)
+	}
+
+	var s summary
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func rateLimitReset(header string) time.Time {
+	var unix int64
+	fmt.Sscanf(header, "%d", &unix)
+	return time.Unix(unix, 0)
+}
+
+func toItem(repo, issue string, s *summary) alfred.Item {
+	kind := "issues"
+	state := s.State
+	if s.PullRequest != nil {
+		kind = "pull"
+		if s.State == "closed" && s.merged() {
+			state = "merged"
+		}
+	}
+
+	excerpt := s.Body
+	if len(excerpt) > 120 {
+		excerpt = strings.TrimSpace(excerpt[:120]) + "…"
+	}
+
+	return alfred.Item{
+		UID:      "gh:" + repo + "#" + issue,
+		Title:    fmt.Sprintf("#%s %s", issue, s.Title),
+		Subtitle: fmt.Sprintf("%s by %s · %s", strings.Title(state), s.User.Login, excerpt),
+		Icon:     stateIcon(state),
+		Arg:      fmt.Sprintf("open https://github.com/%s/%s/%s", repo, kind, issue),
+		Valid:    true,
+	}
+}
+
+func stateIcon(state string) *alfred.Icon {
+	switch state {
+	case "merged":
+		return &alfred.Icon{Path: "icons/merged.png"}
+	case "closed":
+		return &alfred.Icon{Path: "icons/closed.png"}
+	default:
+		return &alfred.Icon{Path: "icons/open.png"}
+	}
+}
@@ -0,0 +1,120 @@
+// Package match implements fzf-style fuzzy subsequence matching, used to
+// offer shorthand completions while the user is still typing.
+package match
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Suggestion is a single shorthand candidate ranked against a query.
+type Suggestion struct {
+	Key   string // the shorthand key, e.g. "zw"
+	Value string // the expansion, e.g. "zerowidth/dotfiles"
+	Score int    // higher is a better match
+}
+
+const (
+	consecutiveBonus = 15
+	boundaryBonus    = 10
+	startBonus       = 10
+	gapPenalty       = 2
+)
+
+// noMatch is returned by Score when query isn't an ordered subsequence of
+// candidate at all. It's distinct from any score a real match can produce
+// (including a low or negative one for a long candidate), so callers can't
+// mistake "no match" for "low-scoring match".
+const noMatch = -1 << 31
+
+// Score returns how well query matches candidate as an ordered
+// subsequence, fzf-style. noMatch means no match; any other value,
+// including zero or negative, is a valid (if low) score. Consecutive runs,
+// matches at the start of the string, and matches after a camelCase/word
+// boundary score higher; each gap before a matched character costs a small
+// flat penalty whatever its length, and each character of the candidate
+// costs one point.
+func Score(query, candidate string) int {
+	if query == "" {
+		return noMatch
+	}
+
+	q := []rune(toLower(query))
+	c := []rune(candidate)
+	cLower := []rune(toLower(candidate))
+
+	score := 0
+	ci := 0
+	prevFound := -1
+	for qi := 0; qi < len(q); qi++ {
+		found := -1
+		for ; ci < len(c); ci++ {
+			if cLower[ci] == q[qi] {
+				found = ci
+				break
+			}
+		}
+		if found == -1 {
+			return noMatch
+		}
+
+		if found == 0 {
+			score += startBonus
+		} else if isBoundary(c, found) {
+			score += boundaryBonus
+		}
+
+		if prevFound >= 0 && found == prevFound+1 {
+			score += consecutiveBonus
+		} else if found > 0 {
+			score -= gapPenalty
+		}
+		prevFound = found
+		ci++
+	}
+
+	// shorter candidates win ties between otherwise-equal matches
+	score -= len(c)
+
+	return score
+}
+
+func isBoundary(c []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := c[i-1], c[i]
+	if prev == '-' || prev == '_' || prev == '/' {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+func toLower(s string) string {
+	r := []rune(s)
+	for i, c := range r {
+		r[i] = unicode.ToLower(c)
+	}
+	return string(r)
+}
+
+// Suggest scores every candidate in candidates against query and returns
+// the matches, best first. Candidates whose key doesn't contain query as a
+// subsequence at all are omitted.
+func Suggest(candidates map[string]string, query string) []Suggestion {
+	suggestions := []Suggestion{}
+	for key, value := range candidates {
+		if s := Score(query, key); s != noMatch {
+			suggestions = append(suggestions, Suggestion{Key: key, Value: value, Score: s})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Key < suggestions[j].Key
+	})
+
+	return suggestions
+}
@@ -0,0 +1,73 @@
+package match
+
+import "testing"
+
+func TestScoreConsecutiveBeatsGapped(t *testing.T) {
+	consecutive := Score("zd", "zd")
+	gapped := Score("zd", "z-gap-d")
+
+	if consecutive <= gapped {
+		t.Fatalf("Score(consecutive) = %d, Score(gapped) = %d; want consecutive > gapped", consecutive, gapped)
+	}
+}
+
+func TestScoreNoMatch(t *testing.T) {
+	if s := Score("xyz", "zerowidth"); s != noMatch {
+		t.Errorf("Score(no match) = %d, want noMatch", s)
+	}
+}
+
+func TestScoreEmptyQuery(t *testing.T) {
+	if s := Score("", "zerowidth"); s != noMatch {
+		t.Errorf("Score(empty query) = %d, want noMatch", s)
+	}
+}
+
+func TestScoreLongCandidateStaysAValidMatch(t *testing.T) {
+	// a long candidate's length penalty can push its score low (even
+	// negative), but a real prefix match must never be reported as noMatch.
+	s := Score("zw", "zerowidth/dotfiles")
+	if s == noMatch {
+		t.Fatalf("Score(valid prefix match on a long candidate) = noMatch, want a real score")
+	}
+
+	candidates := map[string]string{"zerowidth/dotfiles": "zerowidth/dotfiles"}
+	suggestions := Suggest(candidates, "zw")
+	if len(suggestions) != 1 {
+		t.Errorf("Suggest dropped a valid match on a long candidate: %+v", suggestions)
+	}
+}
+
+func TestScoreStartOfStringBeatsMidString(t *testing.T) {
+	start := Score("z", "zerowidth")
+	mid := Score("z", "dotzero")
+
+	if start <= mid {
+		t.Errorf("Score(start) = %d, Score(mid) = %d; want start > mid", start, mid)
+	}
+}
+
+func TestScoreShorterCandidateBreaksTies(t *testing.T) {
+	short := Score("zw", "zw")
+	long := Score("zw", "zwx")
+
+	if short <= long {
+		t.Errorf("Score(short) = %d, Score(long) = %d; want short > long", short, long)
+	}
+}
+
+func TestSuggestOrdersByScoreThenKey(t *testing.T) {
+	candidates := map[string]string{
+		"zd": "zerowidth/dotfiles",
+		"zw": "zerowidth/gh-shorthand",
+		"gh": "cli/cli",
+	}
+
+	suggestions := Suggest(candidates, "z")
+	if len(suggestions) != 2 {
+		t.Fatalf("len(suggestions) = %d, want 2", len(suggestions))
+	}
+	if suggestions[0].Key != "zd" || suggestions[1].Key != "zw" {
+		t.Errorf("suggestions = %+v, want zd before zw (tie broken by key)", suggestions)
+	}
+}
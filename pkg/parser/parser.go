@@ -3,6 +3,8 @@ package parser
 import (
 	"regexp"
 	"strings"
+
+	"github.com/zerowidth/gh-shorthand/pkg/match"
 )
 
 // Parser is a shorthand parser
@@ -14,14 +16,32 @@ type Parser struct {
 	parseRepo   bool // look for a repository match
 	parseUser   bool // look for users
 	parseIssue  bool // look for issues (#123, 123)
+	parsePR     bool // look for pull requests (!123)
 	parsePath   bool // look for /path
 	parseQuery  bool // any extra text
+	parseCode   bool // look for a trailing code search query
+	parseAction bool // look for a leading action verb (i, p, c, r, w, n)
+	parseHost   bool // look for a leading host shorthand (e.g. "work:")
+	hosts       map[string]Host
+}
+
+// Host describes a GitHub-like host addressable via a shorthand prefix
+// (e.g. "work:repo#12"). A matched host's own repo/user maps take over for
+// the rest of the parse, so shorthand can differ per host.
+type Host struct {
+	BaseURL string
+	RepoMap map[string]string
+	UserMap map[string]string
 }
 
 // Option is a functional option to configure a Parser
 type Option func(*Parser)
 
-// NewParser returns a configured Parser
+// NewParser returns a configured Parser. defaultRepo is used when no repo
+// is matched in the input; callers invoked from multiple Alfred
+// keywords/subcommands should resolve the right default repo for that
+// keyword before calling NewParser, rather than have the parser guess one
+// from the parsed input.
 func NewParser(repoMap, userMap map[string]string, defaultRepo string, options ...Option) *Parser {
 	parser := &Parser{
 		repoMap:     repoMap,
@@ -51,35 +71,88 @@ func WithUser(p *Parser) { p.parseUser = true }
 // WithIssue instructs the parser to look for issue (or project) numbers
 func WithIssue(p *Parser) { p.parseIssue = true }
 
+// WithPR instructs the parser to look for a pull request number (!123)
+func WithPR(p *Parser) { p.parsePR = true }
+
+// WithAction instructs the parser to look for a leading action verb
+// (i, p, c, r, w, n) before the repo shorthand, setting NewResult.Action.
+func WithAction(p *Parser) { p.parseAction = true }
+
 // WithPath instructs the parser to look for a path
 func WithPath(p *Parser) { p.parsePath = true }
 
 // WithQuery instructs the parser to match any remaining text as a query
 func WithQuery(p *Parser) { p.parseQuery = true }
 
+// WithCodeQuery instructs the parser to require a repo followed by a
+// free-text code search query, for use with the search package. Unlike
+// WithQuery, this requires a repo and does not try to parse the remaining
+// text as an issue or path first.
+func WithCodeQuery(p *Parser) {
+	p.parseRepo = true
+	p.requireRepo = true
+	p.parseCode = true
+}
+
+// WithHost instructs the parser to look for a host shorthand prefix (e.g.
+// "work:") before the repo shorthand, resolved against hosts.
+func WithHost(hosts map[string]Host) Option {
+	return func(p *Parser) {
+		p.parseHost = true
+		p.hosts = hosts
+	}
+}
+
 // Parse parses the given input and returns a result
 func (p *Parser) Parse(input string) *NewResult {
 	res := &NewResult{}
+	origInput := input
+
+	// repoMap/userMap default to the parser's own maps, but are scoped to
+	// this call only: a host match below substitutes that host's own maps
+	// for the rest of this Parse, without touching the Parser itself so it
+	// stays safe to reuse across calls.
+	repoMap := p.repoMap
+	userMap := p.userMap
+
+	if p.parseAction {
+		if matches := actionRegexp.FindStringSubmatch(input); matches != nil {
+			res.Action = actionVerbs[matches[1]]
+			input = input[len(matches[0]):]
+		}
+	}
+
+	if p.parseHost {
+		if matches := hostRegexp.FindStringSubmatch(input); matches != nil {
+			if host, ok := p.hosts[matches[1]]; ok {
+				res.Host = matches[1]
+				res.HostBaseURL = host.BaseURL
+				repoMap = host.RepoMap
+				userMap = host.UserMap
+				input = input[len(matches[0]):]
+			}
+		}
+	}
 
 	if p.parseRepo {
 		if repo := userRepoRegexp.FindString(input); len(repo) > 0 {
 			// found a repository directly, check for expansion:
 			res.SetRepo(repo)
-			if shortUser, ok := p.userMap[res.User]; ok {
+			if shortUser, ok := userMap[res.User]; ok {
 				res.UserShorthand = res.User
 				res.User = shortUser
 			}
 			input = input[len(repo):]
 		} else if user := userRegexp.FindString(input); len(user) > 0 {
 			// found a user, see if it's repo shorthand:
-			if shortRepo, ok := p.repoMap[user]; ok {
+			if shortRepo, ok := repoMap[user]; ok {
 				res.SetRepo(shortRepo)
 				res.RepoShorthand = user
 				input = input[len(user):]
 			} else if p.parseUser {
 				// not repo shorthand, but we're allowed to match a user:
 				res.User = user
-				if shortUser, ok := p.userMap[user]; ok {
+				if shortUser, ok := userMap[user]; ok {
 					res.UserShorthand = user
 					res.User = shortUser
 				}
@@ -88,7 +161,7 @@ func (p *Parser) Parse(input string) *NewResult {
 		}
 
 		// assign default repository if needed:
-		if p.parseRepo && !res.HasRepo() && len(p.defaultRepo) > 0 {
+		if defaultRepo := p.defaultRepo; p.parseRepo && !res.HasRepo() && defaultRepo != "" {
 			if p.parseUser && res.HasUser() {
 				// if the matched user looks like an issue and there's no further input,
 				// use the default repo and use the numeric user as an issue:
@@ -102,17 +175,22 @@ func (p *Parser) Parse(input string) *NewResult {
 					}
 
 					res.Issue = res.User
-					res.SetRepo(p.defaultRepo)
+					res.SetRepo(defaultRepo)
+					res.UsedDefaultRepo = true
 				}
 			} else {
-				res.SetRepo(p.defaultRepo)
+				res.SetRepo(defaultRepo)
+				res.UsedDefaultRepo = true
 			}
 		}
 	}
 
-	// if we don't have a repo assigned by now, there's no match
+	// if we don't have a repo assigned by now, there's no match: offer
+	// fuzzy completions against the repo/user maps instead of a hard miss
 	if p.requireRepo && !res.HasRepo() {
-		return &NewResult{}
+		res := &NewResult{}
+		res.Suggestions = suggestions(repoMap, userMap, origInput)
+		return res
 	}
 
 	if p.parseIssue {
@@ -122,6 +200,13 @@ func (p *Parser) Parse(input string) *NewResult {
 		}
 	}
 
+	if p.parsePR {
+		if matches := prRegexp.FindStringSubmatch(input); matches != nil {
+			res.PR = matches[1]
+			input = input[len(matches[0]):]
+		}
+	}
+
 	if p.parsePath {
 		if matches := pathRegexp.FindStringSubmatch(input); matches != nil {
 			res.Path = matches[1]
@@ -129,6 +214,25 @@ func (p *Parser) Parse(input string) *NewResult {
 		}
 	}
 
+	if p.parseCode {
+		if res.UsedDefaultRepo {
+			// no repo token was consumed from input, so the whole remaining
+			// input is the query: codeQueryRegexp's leading space only makes
+			// sense when a repo shorthand was actually stripped off first.
+			if query := strings.TrimRight(input, " "); query != "" {
+				res.Query = query
+				input = ""
+			} else {
+				return &NewResult{} // a code query requires a query
+			}
+		} else if matches := codeQueryRegexp.FindStringSubmatch(input); matches != nil {
+			res.Query = matches[1]
+			input = input[len(matches[0]):]
+		} else {
+			return &NewResult{} // a code query requires a query
+		}
+	}
+
 	if p.parseQuery {
 		// only remove the first leading space, and all trailing spaces
 		res.Query = strings.TrimPrefix(strings.TrimRight(input, " "), " ")
@@ -139,42 +243,20 @@ func (p *Parser) Parse(input string) *NewResult {
 	return res
 }
 
-// Parse takes a user and repo mapping along with an input string and attempts
-// to extract a repo, issue, path, or query, using the user and repo mappings
-// for shorthand expansion.
-//
-// bareUser determines whether or not a bare username is allowed as input.
-// ignoreNumeric determines whether or not to ignore a bare user if it's
-// entirely numeric. if true, numeric-only will be parsed as an issue, not user.
-func Parse(repoMap, userMap map[string]string, input string, bareUser, ignoreNumeric bool) Result {
-	var res Result
-
-	if r := userRepoRegexp.FindString(input); len(r) > 0 {
-		res.SetRepo(r)
-		if su, ok := userMap[res.User]; ok {
-			res.UserMatch = res.User
-			res.User = su
-		}
-		input = input[len(r):]
-	} else if u := userRegexp.FindString(input); len(u) > 0 {
-		if sr, ok := repoMap[u]; ok {
-			res.SetRepo(sr)
-			res.RepoMatch = u
-			input = input[len(u):]
-		} else if su, ok := userMap[u]; ok {
-			res.UserMatch = u
-			res.User = su
-			input = input[len(u):]
-		} else if bareUser && (!ignoreNumeric || !issueRegexp.MatchString(input)) {
-			res.User = u
-			input = input[len(u):]
-		}
+// suggestions fuzzy-matches input against the repo and user shorthand maps,
+// for use when input doesn't (yet) resolve to a repo on its own. repoMap and
+// userMap are passed in rather than read off the Parser so that a host
+// match's substituted maps (scoped to a single Parse call) are honored here
+// too.
+func suggestions(repoMap, userMap map[string]string, input string) []match.Suggestion {
+	candidates := make(map[string]string, len(repoMap)+len(userMap))
+	for k, v := range repoMap {
+		candidates[k] = v
 	}
-
-	// only remove the first leading space
-	res.Query = strings.TrimPrefix(strings.TrimRight(input, " "), " ")
-
-	return res
+	for k, v := range userMap {
+		candidates[k] = v
+	}
+	return match.Suggest(candidates, input)
 }
 
 var (
@@ -182,5 +264,26 @@ var (
 	userRepoRegexp = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9]*)/([\w\.\-]*)(\A|\z|\w)`) // user/repo
 	userRegexp     = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9]*)\b`)                     // user
 	issueRegexp    = regexp.MustCompile(`^ ?#?([1-9]\d*)$`)
+	prRegexp       = regexp.MustCompile(`^ ?!([1-9]\d*)$`)
 	pathRegexp     = regexp.MustCompile(`^ ?(/\S*)$`)
+	// codeQueryRegexp requires at least one space before the query so a bare
+	// repo shorthand (no query yet) doesn't match.
+	codeQueryRegexp = regexp.MustCompile(`^ (\S.*)$`)
+	// actionRegexp matches a single-letter action verb prefix, consumed
+	// before the repo shorthand.
+	actionRegexp = regexp.MustCompile(`^(i|p|c|r|w|n) `)
+	// hostRegexp matches a leading host shorthand, e.g. "work:" in
+	// "work:team/api#3".
+	hostRegexp = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9]*):`)
 )
+
+// actionVerbs maps an action verb prefix to the NewResult.Action value used by
+// actionURL's routing table.
+var actionVerbs = map[string]string{
+	"i": "issues",
+	"p": "pulls",
+	"c": "commits",
+	"r": "releases",
+	"w": "wiki",
+	"n": "new-issue",
+}
@@ -0,0 +1,93 @@
+package parser
+
+import "testing"
+
+func TestParseDefaultRepo(t *testing.T) {
+	repoMap := map[string]string{"zw": "zerowidth/dotfiles"}
+	userMap := map[string]string{}
+
+	cases := []struct {
+		name     string
+		options  []Option
+		input    string
+		wantRepo string
+		wantRest string // Issue or Query, whichever the mode sets
+	}{
+		{
+			name:     "issue mode falls back to default repo",
+			options:  []Option{WithRepo, WithUser, WithIssue},
+			input:    "42",
+			wantRepo: "zerowidth/dotfiles",
+			wantRest: "42",
+		},
+		{
+			name:     "query mode falls back to default repo",
+			options:  []Option{RequireRepo, WithQuery},
+			input:    "some free text query",
+			wantRepo: "zerowidth/dotfiles",
+			wantRest: "some free text query",
+		},
+		{
+			name:     "code query mode falls back to default repo",
+			options:  []Option{WithCodeQuery},
+			input:    "some free text query",
+			wantRepo: "zerowidth/dotfiles",
+			wantRest: "some free text query",
+		},
+		{
+			name:     "code query mode still resolves an explicit repo",
+			options:  []Option{WithCodeQuery},
+			input:    "zw some free text query",
+			wantRepo: "zerowidth/dotfiles",
+			wantRest: "some free text query",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := NewParser(repoMap, userMap, "zerowidth/dotfiles", c.options...)
+			res := p.Parse(c.input)
+
+			if res.Repo != c.wantRepo {
+				t.Errorf("Repo = %q, want %q", res.Repo, c.wantRepo)
+			}
+			if !res.UsedDefaultRepo && c.wantRepo != "" && res.RepoShorthand == "" {
+				t.Errorf("UsedDefaultRepo = false, want true when no repo shorthand was matched")
+			}
+
+			got := res.Issue
+			if got == "" {
+				got = res.Query
+			}
+			if got != c.wantRest {
+				t.Errorf("Issue/Query = %q, want %q", got, c.wantRest)
+			}
+		})
+	}
+}
+
+func TestParseReusesHostMapsPerCall(t *testing.T) {
+	repoMap := map[string]string{"zw": "zerowidth/gh-shorthand"}
+	userMap := map[string]string{}
+	hosts := map[string]Host{
+		"work": {
+			BaseURL: "https://ghe.corp",
+			RepoMap: map[string]string{"api": "work-org/api"},
+			UserMap: map[string]string{},
+		},
+	}
+
+	p := NewParser(repoMap, userMap, "", WithRepo, WithIssue, WithHost(hosts))
+
+	res := p.Parse("work:api#3")
+	if res.Repo != "work-org/api" {
+		t.Fatalf("first Parse: Repo = %q, want %q", res.Repo, "work-org/api")
+	}
+
+	// a subsequent call with no host prefix must still resolve the parser's
+	// own maps, not the host's maps from the previous call.
+	res = p.Parse("zw#5")
+	if res.Repo != "zerowidth/gh-shorthand" {
+		t.Fatalf("second Parse: Repo = %q, want %q (parser's own maps must not be pinned to the previous host)", res.Repo, "zerowidth/gh-shorthand")
+	}
+}
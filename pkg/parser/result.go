@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/zerowidth/gh-shorthand/pkg/match"
+)
+
+// NewResult is the outcome of parsing shorthand input with Parser.Parse.
+type NewResult struct {
+	User            string
+	UserShorthand   string
+	Repo            string
+	RepoShorthand   string
+	Issue           string
+	PR              string
+	Path            string
+	Query           string
+	Match           string
+	Action          string
+	Host            string             // matched host shorthand, e.g. "work"
+	HostBaseURL     string             // base URL for the matched host, e.g. "https://ghe.corp"
+	Suggestions     []match.Suggestion // fuzzy completions, when input doesn't resolve to a repo
+	UsedDefaultRepo bool               // true if Repo was filled in from a configured default
+}
+
+// SetRepo splits a "user/repo" shorthand into its User and Repo fields.
+func (r *NewResult) SetRepo(repo string) {
+	r.Repo = repo
+	if idx := strings.IndexByte(repo, '/'); idx >= 0 {
+		r.User = repo[:idx]
+	} else {
+		r.User = repo
+	}
+}
+
+// HasRepo returns true once a repository has been matched.
+func (r *NewResult) HasRepo() bool { return r.Repo != "" }
+
+// HasUser returns true once a user has been matched.
+func (r *NewResult) HasUser() bool { return r.User != "" }
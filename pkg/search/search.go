@@ -0,0 +1,105 @@
+// Package search runs code search against local clones of GitHub
+// repositories, so repos that are already checked out locally can be
+// searched without needing an index.
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zerowidth/gh-shorthand/pkg/alfred"
+)
+
+// MaxResults caps the number of git grep hits turned into Alfred items.
+const MaxResults = 9
+
+// Searcher runs git grep against local clones rooted at CloneRoot.
+type Searcher struct {
+	CloneRoot string // e.g. ~/src/github.com
+	Editor    string // command used to open a file, e.g. "code -g"
+}
+
+// NewSearcher returns a Searcher configured with the given clone root and
+// editor command.
+func NewSearcher(cloneRoot, editor string) *Searcher {
+	return &Searcher{CloneRoot: cloneRoot, Editor: editor}
+}
+
+// clonePath returns the expected local clone path for a repo, e.g.
+// "zerowidth/gh-shorthand" -> "<CloneRoot>/zerowidth/gh-shorthand".
+func (s *Searcher) clonePath(repo string) string {
+	return filepath.Join(s.CloneRoot, repo)
+}
+
+// Search runs `git grep` for query against the local clone of repo. If no
+// local clone is found, a single item opening GitHub's code search is
+// returned instead.
+func (s *Searcher) Search(repo, query string) ([]alfred.Item, error) {
+	clone := s.clonePath(repo)
+
+	if _, err := os.Stat(clone); os.IsNotExist(err) {
+		return []alfred.Item{s.fallbackItem(repo, query)}, nil
+	}
+
+	cmd := exec.Command("git", "grep", "-n", "-I", "--max-count", strconv.Itoa(MaxResults), "--", query)
+	cmd.Dir = clone
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			// no matches: fall back to GitHub code search
+			item := s.fallbackItem(repo, query)
+			item.Subtitle = "no matches in local clone at " + clone
+			return []alfred.Item{item}, nil
+		}
+		return nil, fmt.Errorf("search: git grep: %w", err)
+	}
+
+	return s.toItems(repo, out), nil
+}
+
+func (s *Searcher) toItems(repo string, out []byte) []alfred.Item {
+	items := []alfred.Item{}
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() && len(items) < MaxResults {
+		line := scanner.Text()
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		file, lineNo, text := parts[0], parts[1], strings.TrimSpace(parts[2])
+
+		items = append(items, alfred.Item{
+			UID:      fmt.Sprintf("gh-search:%s/%s:%s", repo, file, lineNo),
+			Title:    fmt.Sprintf("%s:%s", file, lineNo),
+			Subtitle: text,
+			Arg:      s.openArg(repo, file, lineNo),
+			Valid:    true,
+		})
+	}
+	return items
+}
+
+func (s *Searcher) openArg(repo, file, lineNo string) string {
+	if s.Editor != "" {
+		return fmt.Sprintf("%s %s:%s", s.Editor, filepath.Join(s.clonePath(repo), file), lineNo)
+	}
+	return fmt.Sprintf("open https://github.com/%s/blob/HEAD/%s#L%s", repo, file, lineNo)
+}
+
+func (s *Searcher) fallbackItem(repo, query string) alfred.Item {
+	u := fmt.Sprintf("https://github.com/search?q=%s&type=code",
+		url.QueryEscape(fmt.Sprintf("repo:%s %s", repo, query)))
+	return alfred.Item{
+		UID:      "gh-search:" + repo,
+		Title:    fmt.Sprintf("Search %s for %q on GitHub", repo, query),
+		Subtitle: "no local clone found at " + s.clonePath(repo),
+		Arg:      "open " + u,
+		Valid:    true,
+	}
+}